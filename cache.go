@@ -0,0 +1,65 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path"
+	"strconv"
+)
+
+// Hasher computes a hex digest of a byte slice. It exists so the hash
+// function backing the build cache can be swapped (e.g. for a faster,
+// non-cryptographic hash) without touching callers.
+type Hasher interface {
+	Sum(data []byte) string
+}
+
+// sha256Hasher is the default Hasher, using crypto/sha256.
+type sha256Hasher struct{}
+
+func (sha256Hasher) Sum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+var defaultHasher Hasher = sha256Hasher{}
+
+// buildCacheKey derives the content-addressable cache key for a build: the
+// hash of the build command, search depth, and source tree (identified by
+// commit hash) that together determine the resulting binary. Settings that
+// only affect running, like RunCmd or Runs, are deliberately excluded so
+// that benchmarking many settings combinations against one build doesn't
+// require rebuilding.
+func buildCacheKey(h Hasher, buildCmd string, depth int, sourceTree string) string {
+	payload := buildCmd + "|" + strconv.Itoa(depth) + "|" + sourceTree
+	return h.Sum([]byte(payload))
+}
+
+// cacheRoot returns the root of the local content-addressable build cache,
+// ~/.cache/chess-bench, creating it if necessary.
+func cacheRoot() (string, error) {
+	userCache, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	root := path.Join(userCache, "chess-bench")
+	if err := os.MkdirAll(root, os.ModePerm); err != nil {
+		return "", err
+	}
+
+	return root, nil
+}
+
+// cacheBinDir resolves the bin/ directory a commit built with the given
+// settings is (or would be) cached under.
+func cacheBinDir(c *commit, sourceTree string) (string, error) {
+	root, err := cacheRoot()
+	if err != nil {
+		return "", err
+	}
+
+	key := buildCacheKey(defaultHasher, c.Settings.BuildCmd, c.Settings.Depth, sourceTree)
+	return path.Join(root, key, "bin"), nil
+}
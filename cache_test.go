@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestBuildCacheKeyIsDeterministic(t *testing.T) {
+	h := defaultHasher
+	a := buildCacheKey(h, "make -j", 10, "abc123")
+	b := buildCacheKey(h, "make -j", 10, "abc123")
+
+	if a != b {
+		t.Errorf("buildCacheKey is not deterministic: %q != %q", a, b)
+	}
+}
+
+func TestBuildCacheKeyVariesWithBuildInputs(t *testing.T) {
+	h := defaultHasher
+	base := buildCacheKey(h, "make -j", 10, "abc123")
+
+	cases := []struct {
+		name       string
+		buildCmd   string
+		depth      int
+		sourceTree string
+	}{
+		{"different build command", "make clean && make -j", 10, "abc123"},
+		{"different depth", "make -j", 12, "abc123"},
+		{"different commit", "make -j", 10, "def456"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := buildCacheKey(h, c.buildCmd, c.depth, c.sourceTree)
+			if got == base {
+				t.Errorf("buildCacheKey(%q, %d, %q) collided with the base key %q", c.buildCmd, c.depth, c.sourceTree, base)
+			}
+		})
+	}
+}
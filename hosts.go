@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// newShellCmd builds a command that runs shellCmd with dir as its working
+// directory, either locally (host == "") or on a remote machine over SSH.
+// Unlike os.Chdir, this never mutates process-global state, so commands for
+// different commits or hosts can run concurrently.
+func newShellCmd(host, dir, shellCmd string) *exec.Cmd {
+	if host == "" {
+		cmd := exec.Command("bash", "-c", shellCmd)
+		cmd.Dir = dir
+		return cmd
+	}
+
+	remote := fmt.Sprintf("cd %s && %s", shellQuote(dir), shellCmd)
+	return exec.Command("ssh", host, remote)
+}
+
+// shellQuote wraps s in single quotes suitable for passing through ssh's
+// remote shell, escaping any single quotes it contains.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// syncToHost copies localDir's contents to the same absolute path on host
+// over rsync (creating it first), so a remote run has the built binaries
+// available before newShellCmd executes there. It's a no-op for local runs
+// (host == "").
+func syncToHost(host, localDir string) error {
+	if host == "" {
+		return nil
+	}
+
+	mkdir := exec.Command("ssh", host, fmt.Sprintf("mkdir -p %s", shellQuote(localDir)))
+	if out, err := mkdir.CombinedOutput(); err != nil {
+		return fmt.Errorf("Error creating %s on %s: %v (%s)", localDir, host, err, out)
+	}
+
+	rsync := exec.Command("rsync", "-az", "--delete", localDir+"/", host+":"+localDir+"/")
+	if out, err := rsync.CombinedOutput(); err != nil {
+		return fmt.Errorf("Error syncing %s to %s: %v (%s)", localDir, host, err, out)
+	}
+
+	return nil
+}
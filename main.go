@@ -1,22 +1,22 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"log"
 	"os"
 	"os/exec"
 	"path"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-git/go-git/v6"
-	"github.com/go-git/go-git/v6/config"
 	"github.com/go-git/go-git/v6/plumbing"
 	"gonum.org/v1/gonum/stat"
 )
@@ -26,12 +26,47 @@ type settings struct {
 	Depth    int    `json:"depth"`
 	BuildCmd string `json:"buildCmd"`
 	RunCmd   string `json:"runCmd"`
+
+	// Warmup is the number of untimed runs performed before the timed runs
+	// begin, to let the OS page cache and CPU frequency scaling settle. Left
+	// at 0, one warmup run is performed.
+	Warmup int `json:"warmup"`
+	// MinDurationMs and MaxDurationMs bound the total wall-clock time spent
+	// benchmarking a single commit: runs continue past Runs if the minimum
+	// hasn't been reached, and stop early once the maximum is hit.
+	MinDurationMs int `json:"minDurationMs"`
+	MaxDurationMs int `json:"maxDurationMs"`
+	// TrimPercent discards this fraction of the fastest and slowest runs
+	// (e.g. 0.1 trims the bottom and top 10%) before computing statistics.
+	TrimPercent float64 `json:"trimPercent"`
+	// Nice, IONice and Cpuset isolate each run from host scheduling noise.
+	Nice   int    `json:"nice"`
+	IONice int    `json:"ionice"`
+	Cpuset string `json:"cpuset"`
+
+	// Protocol selects how a commit is benchmarked. "" (the default) times
+	// RunCmd end-to-end; "uci" speaks the UCI protocol to RunCmd instead, so
+	// process startup isn't counted as search time.
+	Protocol string `json:"protocol"`
+	// GoMode selects the UCI search command used per position: "depth"
+	// (default), "nodes", or "bench" (Stockfish/Ethereal's built-in bench).
+	GoMode string `json:"goMode"`
+	// EPDFile is a suite of EPD positions (one per line) to search, one
+	// after another, on the same engine process. Left empty, a single
+	// search from the start position is run.
+	EPDFile string `json:"epdFile"`
 }
 
 type commit struct {
 	Hash     string   `json:"hash"`
 	Label    string   `json:"label"`
 	Settings settings `json:"settings"`
+
+	// ResolvedHash is Hash resolved to a full commit SHA once at config-load
+	// time, so a moving ref like "main" or "HEAD" can't advance between the
+	// build, the run and the report and leave them disagreeing about which
+	// physical commit was benchmarked.
+	ResolvedHash string `json:"-"`
 }
 
 type appConfig struct {
@@ -39,6 +74,26 @@ type appConfig struct {
 	RemoteURL    string   `json:"remote"`
 	Commits      []commit `json:"commits"`
 	Pwd          string
+
+	// RegressionThreshold is the two-tailed Welch's t-test p-value below
+	// which two consecutive commits' runtimes are considered a regression.
+	// A value of 0 disables regression detection.
+	RegressionThreshold float64 `json:"regressionThreshold"`
+
+	// ArtifactURL is where built bin/ trees are shared between machines,
+	// e.g. "file:///mnt/shared/chess-bench", "gs://bucket/prefix" or
+	// "s3://bucket/prefix". Left empty, artifacts stay in the local build
+	// cache (see cacheRoot).
+	ArtifactURL string `json:"artifactStore"`
+
+	// BuildConcurrency caps how many commits are built in parallel (-j).
+	// Defaults to 1 (fully serial) when unset.
+	BuildConcurrency int `json:"buildConcurrency"`
+	// Hosts distributes runs (not builds) across a set of machines reached
+	// over SSH, one "user@host" entry per machine. Runs scheduled onto the
+	// same host stay serial, to preserve timing fidelity; hosts run in
+	// parallel with each other. Left empty, all runs happen on localhost.
+	Hosts []string `json:"hosts"`
 }
 
 func dirExists(path string) bool {
@@ -63,6 +118,36 @@ func applySettings(cfgSettings *settings, baseSettings settings) {
 	if cfgSettings.RunCmd == "" {
 		cfgSettings.RunCmd = baseSettings.RunCmd
 	}
+	if cfgSettings.Warmup == 0 {
+		cfgSettings.Warmup = baseSettings.Warmup
+	}
+	if cfgSettings.MinDurationMs == 0 {
+		cfgSettings.MinDurationMs = baseSettings.MinDurationMs
+	}
+	if cfgSettings.MaxDurationMs == 0 {
+		cfgSettings.MaxDurationMs = baseSettings.MaxDurationMs
+	}
+	if cfgSettings.TrimPercent == 0 {
+		cfgSettings.TrimPercent = baseSettings.TrimPercent
+	}
+	if cfgSettings.Nice == 0 {
+		cfgSettings.Nice = baseSettings.Nice
+	}
+	if cfgSettings.IONice == 0 {
+		cfgSettings.IONice = baseSettings.IONice
+	}
+	if cfgSettings.Cpuset == "" {
+		cfgSettings.Cpuset = baseSettings.Cpuset
+	}
+	if cfgSettings.Protocol == "" {
+		cfgSettings.Protocol = baseSettings.Protocol
+	}
+	if cfgSettings.GoMode == "" {
+		cfgSettings.GoMode = baseSettings.GoMode
+	}
+	if cfgSettings.EPDFile == "" {
+		cfgSettings.EPDFile = baseSettings.EPDFile
+	}
 }
 
 func getConfig() (*appConfig, error) {
@@ -83,83 +168,66 @@ func getConfig() (*appConfig, error) {
 	}
 	appConfig.Pwd = pwd
 
+	if err = expandCommitRanges(&appConfig); err != nil {
+		return nil, fmt.Errorf("Error expanding commit ranges: %v", err)
+	}
+
 	for i := range appConfig.Commits {
 		commitSettings := &appConfig.Commits[i].Settings
 		applySettings(commitSettings, appConfig.BaseSettings)
 	}
 
+	for i := range appConfig.Commits {
+		c := &appConfig.Commits[i]
+		hash, err := getHash(c.Hash, &appConfig)
+		if err != nil {
+			return nil, fmt.Errorf("Error resolving commit %q: %v", c.Hash, err)
+		}
+		c.ResolvedHash = hash.String()
+	}
+
 	return &appConfig, nil
 }
 
+// getHash resolves a commit entry's Hash field to a commit hash. A full hex
+// SHA resolves directly with no network access; anything else (a branch, a
+// tag, "HEAD", "HEAD~5", a short SHA, ...) is resolved against the shared
+// refs repo.
 func getHash(h string, cfg *appConfig) (*plumbing.Hash, error) {
-	hash := plumbing.ZeroHash
-
-	if h == "HEAD" {
-		remote := git.NewRemote(nil, &config.RemoteConfig{
-			Name: "origin",
-			URLs: []string{cfg.RemoteURL},
-		})
-
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-
-		refs, err := remote.ListContext(ctx, &git.ListOptions{})
-		if err != nil {
-			return nil, fmt.Errorf("ListContextError: %v", err)
-		}
+	if hash, ok := plumbing.FromHex(h); ok && len(h) == 40 {
+		return &hash, nil
+	}
 
-		var target plumbing.ReferenceName
-		for _, r := range refs {
-			if r.Name() == plumbing.HEAD {
-				if r.Type() == plumbing.SymbolicReference {
-					target = r.Target()
-				} else {
-					hash = r.Hash()
-				}
-				break
-			}
-		}
+	return resolveRevision(h, cfg)
+}
 
-		// If head is symbolic reference, find head from target
-		if target != "" {
-			for _, r := range refs {
-				if r.Name() == target {
-					hash = r.Hash()
-					break
-				}
-			}
-		}
+// hashLocks serializes buildCommit per git hash, so commit entries that share
+// a hash but differ in settings (e.g. the same commit benchmarked at two
+// different depths) don't clone into or build within the same commitDir at
+// the same time when cfg.BuildConcurrency > 1.
+var hashLocks sync.Map // map[string]*sync.Mutex
 
-		if hash == plumbing.ZeroHash {
-			return nil, fmt.Errorf("Failed to find HEAD commit hash")
-		}
-	} else {
-		var ok bool
-		hash, ok = plumbing.FromHex(h)
-		if !ok {
-			return nil, fmt.Errorf("Failed to parse commit hash: %v", h)
-		}
-	}
-
-	return &hash, nil
+func lockForHash(hash string) *sync.Mutex {
+	mu, _ := hashLocks.LoadOrStore(hash, &sync.Mutex{})
+	return mu.(*sync.Mutex)
 }
 
 func buildCommit(c *commit, cfg *appConfig) error {
-	hash, err := getHash(c.Hash, cfg)
-	if err != nil {
-		return fmt.Errorf("Error getting hash: %v", err)
-	}
+	hash := plumbing.NewHash(c.ResolvedHash)
 	partialHash := hash.String()[:7]
 
+	mu := lockForHash(hash.String())
+	mu.Lock()
+	defer mu.Unlock()
+
 	commitDir := path.Join(cfg.Pwd, "build", hash.String())
 
 	// Clone repo
 	if !dirExists(commitDir) {
 		fmt.Printf("Cloning into %s...\n", commitDir)
-		r, err := git.PlainClone(commitDir, &git.CloneOptions{
+		r, err := git.PlainCloneContext(context.Background(), commitDir, &git.CloneOptions{
 			URL:        cfg.RemoteURL,
 			NoCheckout: true,
-			Progress:   os.Stdout,
 		})
 		if err != nil {
 			return fmt.Errorf("Error cloning repo: %v", err)
@@ -178,165 +246,376 @@ func buildCommit(c *commit, cfg *appConfig) error {
 		}
 	}
 
-	// Check SHA of commit settings
-	json, err := json.Marshal(c.Settings)
+	// Resolve the content-addressable cache entry for this (BuildCmd, Depth,
+	// commit) combination, keyed independently of settings like RunCmd that
+	// don't affect the build, so other settings can reuse it without
+	// rebuilding or clobbering this entry.
+	cacheBin, err := cacheBinDir(c, hash.String())
 	if err != nil {
-		return fmt.Errorf("Error marshalling commit settings: %v", err)
+		return fmt.Errorf("Error resolving build cache dir: %v", err)
 	}
-	fmt.Printf("Checking SHA: %v\n", partialHash)
-	sha := sha256.Sum256(json)
-	shaFile := path.Join(commitDir, "__bench")
-	rebuild := false
-
-	_, err = os.Stat(shaFile)
-	if err == nil {
-		// File exists
-		data, err := os.ReadFile(shaFile)
-		if err != nil {
-			return fmt.Errorf("Error reading SHA file: %v")
-		}
 
-		// Only rebuild if settings have changed
-		rebuild = !bytes.Equal(data, sha[:])
-		if rebuild {
-			fmt.Println("SHA mismatch")
-		} else {
-			fmt.Println("SHA verified")
-		}
+	if dirExists(cacheBin) {
+		fmt.Printf("Cache hit for %v, skipping build\n", partialHash)
+		return nil
 	}
 
-	if rebuild || errors.Is(err, os.ErrNotExist) {
-		err = os.WriteFile(shaFile, sha[:], 0644)
-		if err != nil {
-			return fmt.Errorf("Failed to write SHA: %v", err)
-		}
-		fmt.Println("SHA written")
+	// Build project. cmd.Dir (rather than os.Chdir, which is process-global)
+	// keeps this safe to run concurrently with other commits' builds.
+	fmt.Printf("Building %s...\n", partialHash)
+	cmd := exec.Command("bash", "-c", c.Settings.BuildCmd)
+	cmd.Dir = commitDir
+	err = cmd.Run()
+	if err != nil {
+		return fmt.Errorf("Error building commit: %v", err)
+	}
+	fmt.Println("Build complete")
 
-		rebuild = true
-	} else {
-		return fmt.Errorf("Error with Stat: %v", err)
+	if err := copyTree(path.Join(commitDir, "bin"), cacheBin); err != nil {
+		return fmt.Errorf("Error populating build cache: %v", err)
 	}
 
-	// Build project
-	if rebuild {
-		fmt.Printf("Building %s...\n", partialHash)
-		err = os.Chdir(commitDir)
+	if cfg.ArtifactURL != "" {
+		settingsJSON, err := json.Marshal(c.Settings)
 		if err != nil {
-			return fmt.Errorf("Error changing dirs: %v", err)
+			return fmt.Errorf("Error marshalling commit settings: %v", err)
 		}
-		cmd := exec.Command("bash", "-c", c.Settings.BuildCmd)
-		err = cmd.Run()
+		sha := sha256.Sum256(settingsJSON)
+
+		store, err := NewStorage(cfg.ArtifactURL)
 		if err != nil {
-			return fmt.Errorf("Error building commit: %v", err)
+			return fmt.Errorf("Error opening artifact store: %v", err)
+		}
+
+		key := artifactKey(hash.String(), sha[:])
+		fmt.Printf("Uploading %s to artifact store...\n", key)
+		if err := store.Upload(context.Background(), key, cacheBin); err != nil {
+			return fmt.Errorf("Error uploading build artifacts: %v", err)
 		}
-		fmt.Println("Build complete")
 	}
 
 	return nil
 }
 
+// artifactKey derives the storage key a commit's build artifacts are shared
+// under: "{commitHash}/{settingsSHA}", so that the same commit built with
+// different settings doesn't collide.
+func artifactKey(commitHash string, settingsSHA []byte) string {
+	return path.Join(commitHash, hex.EncodeToString(settingsSHA))
+}
+
+// buildCommits builds every configured commit, running up to
+// cfg.BuildConcurrency builds in parallel. Each commit's error (if any) is
+// collected rather than aborting the rest of the batch.
 func buildCommits(cfg *appConfig) error {
 	buildPath := path.Join(cfg.Pwd, "build")
 	os.MkdirAll(buildPath, os.ModePerm)
 
-	var err error
+	concurrency := cfg.BuildConcurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	sem := make(chan struct{}, concurrency)
+
+	errs := make([]error, len(cfg.Commits))
+	var wg sync.WaitGroup
 	for i := range cfg.Commits {
-		err = buildCommit(&cfg.Commits[i], cfg)
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			errs[i] = buildCommit(&cfg.Commits[i], cfg)
+		}(i)
+	}
+	wg.Wait()
+
+	var failed []string
+	for i, err := range errs {
 		if err != nil {
-			log.Fatalf("Build error: %v", err)
+			failed = append(failed, fmt.Sprintf("%s: %v", cfg.Commits[i].Label, err))
 		}
 	}
+	if len(failed) > 0 {
+		return fmt.Errorf("Build errors:\n%s", strings.Join(failed, "\n"))
+	}
 
 	return nil
 }
 
-func runCommit(c *commit, cfg *appConfig) error {
-	// Get commit hash and build dir path
-	hash, err := getHash(c.Hash, cfg)
+// isolatedRunCmd wraps runCmd with nice/ionice/cpuset prefixes so that a run
+// can be isolated from other load on the benchmarking host.
+func isolatedRunCmd(runCmd string, s settings) string {
+	prefix := ""
+	if s.Nice != 0 {
+		prefix += fmt.Sprintf("nice -n %d ", s.Nice)
+	}
+	if s.IONice != 0 {
+		prefix += fmt.Sprintf("ionice -c2 -n%d ", s.IONice)
+	}
+	if s.Cpuset != "" {
+		prefix += fmt.Sprintf("taskset -c %s ", s.Cpuset)
+	}
+
+	if prefix == "" {
+		return runCmd
+	}
+
+	return prefix + runCmd
+}
+
+// appendRunRecord appends a structured per-run record to commitDir/runs.jsonl
+// so that raw runs can be diffed across benchmarking sessions later.
+func appendRunRecord(commitDir string, rec runRecord) error {
+	f, err := os.OpenFile(path.Join(commitDir, "runs.jsonl"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
-		return fmt.Errorf("Error getting hash: %v", err)
+		return err
 	}
+	defer f.Close()
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// runCommit benchmarks a single commit on host ("" for localhost) and
+// returns its trimmed, per-run millisecond timings (or, with the "uci"
+// protocol, its trimmed per-position NPS) so the caller can compare them
+// against a previous commit's runs.
+func runCommit(c *commit, cfg *appConfig, host string) ([]float64, error) {
+	// Get commit hash and build dir path
+	hash := plumbing.NewHash(c.ResolvedHash)
 	partialHash := hash.String()
 	if len(partialHash) >= 8 {
 		partialHash = partialHash[:7]
 	}
 
-	// Make sure commit is present and built
-	commitDir := path.Join(cfg.Pwd, "build", hash.String())
-	binDir := path.Join(commitDir, "bin")
-	if !dirExists(commitDir) {
-		return fmt.Errorf("Failed to locate commit directory for %v", partialHash)
+	// Resolve the build's bin/ from the local content-addressable cache,
+	// downloading it from the artifact store on demand if it's missing.
+	binDir, err := cacheBinDir(c, hash.String())
+	if err != nil {
+		return nil, fmt.Errorf("Error resolving build cache dir: %v", err)
 	}
 	if !dirExists(binDir) {
-		return fmt.Errorf("Failed to locate bin directory for %v", partialHash)
+		if cfg.ArtifactURL == "" {
+			return nil, fmt.Errorf("Failed to locate cached build for %v", partialHash)
+		}
+
+		settingsJSON, err := json.Marshal(c.Settings)
+		if err != nil {
+			return nil, fmt.Errorf("Error marshalling commit settings: %v", err)
+		}
+		sha := sha256.Sum256(settingsJSON)
+
+		store, err := NewStorage(cfg.ArtifactURL)
+		if err != nil {
+			return nil, fmt.Errorf("Error opening artifact store: %v", err)
+		}
+
+		key := artifactKey(hash.String(), sha[:])
+		fmt.Printf("Downloading %s from artifact store...\n", key)
+		if err := store.Download(context.Background(), key, binDir); err != nil {
+			return nil, fmt.Errorf("Failed to download build artifacts for %v: %v", partialHash, err)
+		}
 	}
 
-	err = os.Chdir(commitDir)
-	if err != nil {
-		return fmt.Errorf("Error changing dirs: %v", err)
+	// Stage the build on host before running anything there - binDir is a
+	// path on the machine running chess-bench, not synced to remote hosts on
+	// its own.
+	if err := syncToHost(host, binDir); err != nil {
+		return nil, fmt.Errorf("Error staging build on host %s: %v", host, err)
+	}
+
+	// runDir (rather than os.Chdir, which is process-global) keeps this safe
+	// to run concurrently with other commits' or hosts' runs.
+	runDir := path.Dir(binDir)
+
+	// The commit's own dir (as opposed to the cache entry) is only used to
+	// keep a history of raw per-run records across settings.
+	commitDir := path.Join(cfg.Pwd, "build", hash.String())
+	if err = os.MkdirAll(commitDir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("Error creating commit dir: %v", err)
+	}
+
+	if c.Settings.Protocol == "uci" {
+		nps, err := runUCICommit(c, partialHash, host, runDir)
+		if err != nil {
+			return nil, err
+		}
+		return trimOutliers(nps, c.Settings.TrimPercent), nil
 	}
 
-	// Run commit
-	var cmd *exec.Cmd
 	runCmd := strings.ReplaceAll(c.Settings.RunCmd, "%p", strconv.Itoa(c.Settings.Depth))
+	runCmd = isolatedRunCmd(runCmd, c.Settings)
 
-	// Run once to 'warm up' program
-	cmd = exec.Command("bash", "-c", runCmd)
-	err = cmd.Run()
-	if err != nil {
-		return fmt.Errorf("Failed to pre-bench run: %v\n", err)
+	warmup := c.Settings.Warmup
+	if warmup == 0 {
+		warmup = 1
+	}
+	for i := 0; i < warmup; i++ {
+		cmd := newShellCmd(host, runDir, runCmd)
+		if err = cmd.Run(); err != nil {
+			return nil, fmt.Errorf("Failed to pre-bench run %d: %v", i+1, err)
+		}
 	}
 
 	fmt.Printf("Benchmarking %s... (%s)\n", partialHash, c.Label)
 
-	runs := make([]float64, c.Settings.Runs, c.Settings.Runs)
-	for i := 0; i < c.Settings.Runs; i++ {
-		cmd = exec.Command("bash", "-c", runCmd)
+	minDuration := time.Duration(c.Settings.MinDurationMs) * time.Millisecond
+	maxDuration := time.Duration(c.Settings.MaxDurationMs) * time.Millisecond
+
+	var runs []float64
+	budgetStart := time.Now()
+	for i := 0; i < c.Settings.Runs || time.Since(budgetStart) < minDuration; i++ {
+		if maxDuration > 0 && time.Since(budgetStart) >= maxDuration {
+			break
+		}
+
+		cmd := newShellCmd(host, runDir, runCmd)
 
 		start := time.Now()
 		err = cmd.Run()
 		elapsed := time.Since(start)
 		if err != nil {
-			return fmt.Errorf("Failed to perform run %d: %v\n", i+1, err)
+			return nil, fmt.Errorf("Failed to perform run %d: %v", i+1, err)
 		}
 
 		// Store milliseconds as micros / 1000 for more precision
-		runs[i] = float64(elapsed.Microseconds()) / 1000.0
+		millis := float64(elapsed.Microseconds()) / 1000.0
+		runs = append(runs, millis)
+
+		if err = appendRunRecord(commitDir, runRecord{
+			Index:     i,
+			Millis:    millis,
+			Timestamp: start.Unix(),
+			Commit:    hash.String(),
+			Label:     c.Label,
+		}); err != nil {
+			return nil, fmt.Errorf("Error appending run record: %v", err)
+		}
 	}
 
-	avgMillis := stat.Mean(runs, nil)
-	stdDev := stat.StdDev(runs, nil)
+	trimmed := trimOutliers(runs, c.Settings.TrimPercent)
+	avgMillis := stat.Mean(trimmed, nil)
+	stdDev := stat.StdDev(trimmed, nil)
+	sortedTrimmed := append([]float64{}, trimmed...)
+	sort.Float64s(sortedTrimmed)
+	median := stat.Quantile(0.5, stat.Empirical, sortedTrimmed, nil)
+	ciLo, ciHi := bootstrapCI(trimmed, 2000, 0.95)
 
 	// Print results
-	fmt.Printf("Runtimes (n=%d): { %.2f", c.Settings.Runs, runs[0])
+	fmt.Printf("Runtimes (n=%d, trimmed=%d): { %.2f", len(runs), len(trimmed), runs[0])
 	for _, r := range runs[1:] {
 		fmt.Printf(", %.2f", r)
 	}
 	fmt.Printf(" }\n")
-	fmt.Printf("Average runtime: %.2fms (Ïƒ=%.3f)\n", avgMillis, stdDev)
+	fmt.Printf("Mean: %.2fms (σ=%.3f)  Median: %.2fms  95%% CI: [%.2f, %.2f]\n", avgMillis, stdDev, median, ciLo, ciHi)
 
-	return nil
+	return trimmed, nil
+}
+
+// runOutcome is a single commit's run result or error, collected so that one
+// commit failing doesn't abort the rest of the batch.
+type runOutcome struct {
+	runs []float64
+	err  error
 }
 
+// runCommits benchmarks every configured commit, distributed across
+// cfg.Hosts (or just localhost if empty). Commits assigned to the same host
+// run serially, to preserve timing fidelity, but different hosts run
+// concurrently with each other.
 func runCommits(cfg *appConfig) {
 	buildPath := path.Join(cfg.Pwd, "build")
 	os.MkdirAll(buildPath, os.ModePerm)
 
 	fmt.Println("Running commits")
-	for _, c := range cfg.Commits {
-		err := runCommit(&c, cfg)
-		if err != nil {
-			log.Fatalf("Run error: %v", err)
+
+	hosts := cfg.Hosts
+	if len(hosts) == 0 {
+		hosts = []string{""}
+	}
+
+	queues := make([][]int, len(hosts))
+	hostOf := make([]string, len(cfg.Commits))
+	for i := range cfg.Commits {
+		h := i % len(hosts)
+		queues[h] = append(queues[h], i)
+		hostOf[i] = hosts[h]
+	}
+
+	outcomes := make([]runOutcome, len(cfg.Commits))
+	var wg sync.WaitGroup
+	for hi, host := range hosts {
+		wg.Add(1)
+		go func(host string, indices []int) {
+			defer wg.Done()
+			for _, i := range indices {
+				runs, err := runCommit(&cfg.Commits[i], cfg, host)
+				outcomes[i] = runOutcome{runs: runs, err: err}
+			}
+		}(host, queues[hi])
+	}
+	wg.Wait()
+
+	var results []benchResult
+	var prevRuns []float64
+	var prevLabel string
+	regressionP := -1.0
+	var regressionT, regressionDF float64
+	var regressionA, regressionB string
+	for i := range cfg.Commits {
+		c := &cfg.Commits[i]
+		outcome := outcomes[i]
+		if outcome.err != nil {
+			fmt.Printf("Run error for %s: %v\n", c.Label, outcome.err)
+			continue
+		}
+
+		results = append(results, buildBenchResult(cfg, c, c.ResolvedHash, hostOf[i], outcome.runs))
+
+		// Only remember the first regression: writeReport still needs to run
+		// over every commit below before we act on it.
+		if cfg.RegressionThreshold > 0 && prevRuns != nil && regressionP < 0 {
+			t, df, p := welchTTest(outcome.runs, prevRuns)
+			if p < cfg.RegressionThreshold {
+				regressionT, regressionDF, regressionP = t, df, p
+				regressionA, regressionB = prevLabel, c.Label
+			}
 		}
+
+		prevRuns, prevLabel = outcome.runs, c.Label
+	}
+
+	if err := writeReport(cfg, results); err != nil {
+		fmt.Println(err)
+	}
+
+	if regressionP >= 0 {
+		fmt.Printf("Regression detected between %q and %q (t=%.3f, df=%.1f, p=%.4f)\n", regressionA, regressionB, regressionT, regressionDF, regressionP)
+		os.Exit(1)
 	}
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "report" {
+		if err := runReportCommand(os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	config, err := getConfig()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	buildCommits(config)
+	if err := buildCommits(config); err != nil {
+		fmt.Println(err)
+	}
 	runCommits(config)
 }
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+)
+
+// refsNetworkTimeout bounds the clone/fetch calls in openRefsRepo, matching
+// the timeout the baseline used for its equivalent network call. Without it,
+// one unreachable or slow remote would hang config loading (ref and range
+// resolution in getConfig) and the parent lookups in buildBenchResult.
+const refsNetworkTimeout = 10 * time.Second
+
+// refsMu serializes all access to the shared refs repo, since go-git's
+// filesystem storage isn't safe for concurrent Open/Fetch/Log/ResolveRevision
+// calls. Builds and runs use the hash resolved at config-load time and don't
+// touch the refs repo themselves.
+var refsMu sync.Mutex
+
+// withRefsRepo opens the shared refs repo (cloning on first use) and calls fn
+// with it, holding refsMu for the duration so concurrent callers can't race
+// on the same on-disk repo.
+func withRefsRepo(cfg *appConfig, fn func(*git.Repository) error) error {
+	refsMu.Lock()
+	defer refsMu.Unlock()
+
+	r, err := openRefsRepo(cfg)
+	if err != nil {
+		return err
+	}
+	return fn(r)
+}
+
+// refsRepoDir is a single shared clone (all branches and tags, no checkout)
+// used purely to resolve revision expressions like "main", "v1.2.3" or
+// "HEAD~5" to a commit hash, without needing a full per-commit clone first.
+func refsRepoDir(cfg *appConfig) string {
+	return path.Join(cfg.Pwd, "build", ".refs")
+}
+
+// openRefsRepo returns the shared refs repo, cloning it on first use and
+// fetching the latest refs on subsequent calls so branch/tag names stay
+// current across runs.
+func openRefsRepo(cfg *appConfig) (*git.Repository, error) {
+	dir := refsRepoDir(cfg)
+
+	if !dirExists(dir) {
+		fmt.Printf("Cloning refs into %s...\n", dir)
+
+		ctx, cancel := context.WithTimeout(context.Background(), refsNetworkTimeout)
+		defer cancel()
+
+		r, err := git.PlainCloneContext(ctx, dir, &git.CloneOptions{
+			URL:        cfg.RemoteURL,
+			NoCheckout: true,
+			Tags:       git.AllTags,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Error cloning refs repo: %v", err)
+		}
+
+		return r, nil
+	}
+
+	r, err := git.PlainOpen(dir)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening refs repo: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), refsNetworkTimeout)
+	defer cancel()
+
+	err = r.FetchContext(ctx, &git.FetchOptions{Tags: git.AllTags})
+	if err != nil && err != git.NoErrAlreadyUpToDate {
+		return nil, fmt.Errorf("Error fetching refs repo: %v", err)
+	}
+
+	return r, nil
+}
+
+// resolveRevision resolves any git revision expression - a branch, a tag, a
+// short or full SHA, "HEAD~N", "main^{commit}", etc. - to a commit hash,
+// using the shared refs repo.
+func resolveRevision(h string, cfg *appConfig) (*plumbing.Hash, error) {
+	var hash *plumbing.Hash
+	err := withRefsRepo(cfg, func(r *git.Repository) error {
+		resolved, err := r.ResolveRevision(plumbing.Revision(h))
+		if err != nil {
+			return fmt.Errorf("Error resolving revision %q: %v", h, err)
+		}
+		hash = resolved
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hash, nil
+}
+
+// isCommitRange reports whether h is a "from..to" range expression, and if
+// so returns its endpoints.
+func isCommitRange(h string) (from, to string, ok bool) {
+	parts := strings.SplitN(h, "..", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+
+	return parts[0], parts[1], true
+}
+
+// expandCommitRange resolves a "from..to" range into the individual commit
+// hashes from "to" back to, but excluding, "from", oldest first. The walk
+// follows the commit log from "to" and stops the first time it reaches
+// "from", so for linear history the result matches
+// `git rev-list --reverse from..to`; on history with merges, commits the walk
+// would only visit after "from" are not included. It errors if "from" is
+// never reached while walking back from "to" (e.g. the two have diverged),
+// rather than silently returning the whole history up to "to".
+func expandCommitRange(from, to string, cfg *appConfig) ([]string, error) {
+	var hashes []string
+
+	err := withRefsRepo(cfg, func(r *git.Repository) error {
+		fromHash, err := r.ResolveRevision(plumbing.Revision(from))
+		if err != nil {
+			return fmt.Errorf("Error resolving range start %q: %v", from, err)
+		}
+		toHash, err := r.ResolveRevision(plumbing.Revision(to))
+		if err != nil {
+			return fmt.Errorf("Error resolving range end %q: %v", to, err)
+		}
+
+		iter, err := r.Log(&git.LogOptions{From: *toHash})
+		if err != nil {
+			return fmt.Errorf("Error walking commit log: %v", err)
+		}
+		defer iter.Close()
+
+		found := false
+		for {
+			c, err := iter.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return fmt.Errorf("Error walking commit log: %v", err)
+			}
+			if c.Hash == *fromHash {
+				found = true
+				break
+			}
+			hashes = append(hashes, c.Hash.String())
+		}
+		if !found {
+			return fmt.Errorf("%q is not an ancestor of %q, cannot expand range %s..%s", from, to, from, to)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The walk above visits newest-first; reverse so the range expands oldest first.
+	for i, j := 0, len(hashes)-1; i < j; i, j = i+1, j-1 {
+		hashes[i], hashes[j] = hashes[j], hashes[i]
+	}
+
+	return hashes, nil
+}
+
+// expandCommitRanges replaces any commit entry whose Hash is a "from..to"
+// range with one entry per commit in that range, so users can benchmark a
+// whole branch without listing every commit by hand.
+func expandCommitRanges(cfg *appConfig) error {
+	var expanded []commit
+
+	for _, c := range cfg.Commits {
+		from, to, ok := isCommitRange(c.Hash)
+		if !ok {
+			expanded = append(expanded, c)
+			continue
+		}
+
+		hashes, err := expandCommitRange(from, to, cfg)
+		if err != nil {
+			return fmt.Errorf("Error expanding range %q: %v", c.Hash, err)
+		}
+
+		for _, h := range hashes {
+			entry := c
+			entry.Hash = h
+			entry.Label = fmt.Sprintf("%s@%s", c.Label, h[:7])
+			expanded = append(expanded, entry)
+		}
+	}
+
+	cfg.Commits = expanded
+	return nil
+}
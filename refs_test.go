@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"github.com/go-git/go-git/v6/plumbing/object"
+)
+
+func TestIsCommitRange(t *testing.T) {
+	cases := []struct {
+		name     string
+		h        string
+		wantFrom string
+		wantTo   string
+		wantOK   bool
+	}{
+		{"a simple range", "main..HEAD", "main", "HEAD", true},
+		{"a plain hash is not a range", "abc123", "", "", false},
+		{"missing start is not a range", "..HEAD", "", "", false},
+		{"missing end is not a range", "main..", "", "", false},
+		{"extra dots go into the end", "main..HEAD~2..1", "main", "HEAD~2..1", true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			from, to, ok := isCommitRange(c.h)
+			if ok != c.wantOK || from != c.wantFrom || to != c.wantTo {
+				t.Errorf("isCommitRange(%q) = (%q, %q, %v), want (%q, %q, %v)", c.h, from, to, ok, c.wantFrom, c.wantTo, c.wantOK)
+			}
+		})
+	}
+}
+
+// commitFile writes name in repoDir's worktree and commits it, returning the
+// new commit's hash.
+func commitFile(t *testing.T, w *git.Worktree, repoDir, name string) string {
+	t.Helper()
+
+	if err := os.WriteFile(path.Join(repoDir, name), []byte(name), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Add(name); err != nil {
+		t.Fatal(err)
+	}
+
+	hash, err := w.Commit(name, &git.CommitOptions{
+		Author: &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return hash.String()
+}
+
+func TestExpandCommitRange(t *testing.T) {
+	repoDir := t.TempDir()
+	r, err := git.PlainInit(repoDir, false)
+	if err != nil {
+		t.Fatalf("PlainInit failed: %v", err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatalf("Worktree failed: %v", err)
+	}
+
+	first := commitFile(t, w, repoDir, "a")
+	second := commitFile(t, w, repoDir, "b")
+	third := commitFile(t, w, repoDir, "c")
+
+	cfg := &appConfig{RemoteURL: repoDir, Pwd: t.TempDir()}
+
+	hashes, err := expandCommitRange(first, third, cfg)
+	if err != nil {
+		t.Fatalf("expandCommitRange failed: %v", err)
+	}
+	if len(hashes) != 2 || hashes[0] != second || hashes[1] != third {
+		t.Fatalf("expandCommitRange(%s..%s) = %v, want [%s %s]", first[:7], third[:7], hashes, second, third)
+	}
+}
+
+func TestExpandCommitRangeErrorsOnNonAncestor(t *testing.T) {
+	repoDir := t.TempDir()
+	r, err := git.PlainInit(repoDir, false)
+	if err != nil {
+		t.Fatalf("PlainInit failed: %v", err)
+	}
+	w, err := r.Worktree()
+	if err != nil {
+		t.Fatalf("Worktree failed: %v", err)
+	}
+
+	root := commitFile(t, w, repoDir, "a")
+
+	// Branch off root instead of continuing on the default branch, so
+	// "diverged" is reachable in the repo (ResolveRevision succeeds) but is
+	// not an ancestor of "b".
+	rootHash := plumbing.NewHash(root)
+	if err := w.Checkout(&git.CheckoutOptions{
+		Branch: plumbing.NewBranchReferenceName("diverged"),
+		Hash:   rootHash,
+		Create: true,
+	}); err != nil {
+		t.Fatalf("Checkout failed: %v", err)
+	}
+	diverged := commitFile(t, w, repoDir, "c")
+
+	cfg := &appConfig{RemoteURL: repoDir, Pwd: t.TempDir()}
+
+	if _, err := expandCommitRange(diverged, root, cfg); err == nil {
+		t.Fatalf("expandCommitRange with a non-ancestor start: want an error, got nil")
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/go-git/go-git/v6"
+	"github.com/go-git/go-git/v6/plumbing"
+	"gonum.org/v1/gonum/stat"
+)
+
+// benchResult is everything about one commit's benchmark run worth keeping
+// around for later comparison: its raw per-run timings (or per-position NPS,
+// for UCI-mode commits), summary statistics, and the metadata needed to
+// identify it in a report.
+type benchResult struct {
+	CommitHash string    `json:"commitHash"`
+	ParentHash string    `json:"parentHash,omitempty"`
+	Label      string    `json:"label"`
+	Settings   settings  `json:"settings"`
+	Host       string    `json:"host"`
+	Timestamp  int64     `json:"timestamp"`
+	Runs       []float64 `json:"runs"`
+	Mean       float64   `json:"mean"`
+	StdDev     float64   `json:"stdDev"`
+	Median     float64   `json:"median"`
+	CILow      float64   `json:"ciLow"`
+	CIHigh     float64   `json:"ciHigh"`
+}
+
+// runReport is a full benchmarking session: every commit's benchResult,
+// written to results/{timestamp}.json.
+type runReport struct {
+	Timestamp int64         `json:"timestamp"`
+	Results   []benchResult `json:"results"`
+}
+
+// buildBenchResult summarizes a commit's raw runs (already trimmed by
+// runCommit) into a benchResult, resolving its parent commit from the
+// shared refs repo when available.
+func buildBenchResult(cfg *appConfig, c *commit, hash string, host string, runs []float64) benchResult {
+	sorted := append([]float64{}, runs...)
+	sort.Float64s(sorted)
+
+	mean := stat.Mean(runs, nil)
+	stdDev := stat.StdDev(runs, nil)
+	median := stat.Quantile(0.5, stat.Empirical, sorted, nil)
+	ciLow, ciHigh := bootstrapCI(runs, 2000, 0.95)
+
+	parent := ""
+	_ = withRefsRepo(cfg, func(r *git.Repository) error {
+		commitObj, err := r.CommitObject(plumbing.NewHash(hash))
+		if err != nil {
+			return nil
+		}
+		if p, err := commitObj.Parent(0); err == nil {
+			parent = p.Hash.String()
+		}
+		return nil
+	})
+
+	return benchResult{
+		CommitHash: hash,
+		ParentHash: parent,
+		Label:      c.Label,
+		Settings:   c.Settings,
+		Host:       host,
+		Timestamp:  time.Now().Unix(),
+		Runs:       runs,
+		Mean:       mean,
+		StdDev:     stdDev,
+		Median:     median,
+		CILow:      ciLow,
+		CIHigh:     ciHigh,
+	}
+}
+
+// writeReport writes a full session to results/{timestamp}.json and appends
+// each result to results/history.jsonl so results can be diffed across
+// sessions without loading every full report.
+func writeReport(cfg *appConfig, results []benchResult) error {
+	resultsDir := path.Join(cfg.Pwd, "results")
+	if err := os.MkdirAll(resultsDir, os.ModePerm); err != nil {
+		return fmt.Errorf("Error creating results dir: %v", err)
+	}
+
+	report := runReport{Timestamp: time.Now().Unix(), Results: results}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Error marshalling report: %v", err)
+	}
+
+	reportPath := path.Join(resultsDir, fmt.Sprintf("%d.json", report.Timestamp))
+	if err := os.WriteFile(reportPath, data, 0644); err != nil {
+		return fmt.Errorf("Error writing report: %v", err)
+	}
+	fmt.Printf("Wrote report to %s\n", reportPath)
+
+	f, err := os.OpenFile(path.Join(resultsDir, "history.jsonl"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("Error opening history file: %v", err)
+	}
+	defer f.Close()
+
+	for _, result := range results {
+		line, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("Error marshalling history entry: %v", err)
+		}
+		if _, err := f.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("Error writing history entry: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// readReport loads a runReport previously written by writeReport.
+func readReport(reportPath string) (*runReport, error) {
+	data, err := os.ReadFile(reportPath)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading report %s: %v", reportPath, err)
+	}
+
+	var report runReport
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("Error parsing report %s: %v", reportPath, err)
+	}
+
+	return &report, nil
+}
+
+// matchResults pairs each entry in a with its counterpart in b by commit
+// hash (falling back to label when the hash isn't present in b), returning
+// matched pairs in a's order alongside whatever on either side had no
+// counterpart, so callers can compare like for like instead of zipping by
+// position.
+func matchResults(a, b []benchResult) (pairs [][2]benchResult, unmatchedA, unmatchedB []benchResult) {
+	byHash := make(map[string]benchResult, len(b))
+	byLabel := make(map[string]benchResult, len(b))
+	for _, r := range b {
+		byHash[r.CommitHash] = r
+		byLabel[r.Label] = r
+	}
+	matched := make(map[string]bool, len(b))
+
+	for _, ra := range a {
+		rb, ok := byHash[ra.CommitHash]
+		if !ok {
+			rb, ok = byLabel[ra.Label]
+		}
+		if !ok {
+			unmatchedA = append(unmatchedA, ra)
+			continue
+		}
+		matched[rb.CommitHash] = true
+		pairs = append(pairs, [2]benchResult{ra, rb})
+	}
+
+	for _, rb := range b {
+		if !matched[rb.CommitHash] {
+			unmatchedB = append(unmatchedB, rb)
+		}
+	}
+
+	return pairs, unmatchedA, unmatchedB
+}
+
+// runReportCommand implements `chess-bench report <a.json> <b.json>`: a
+// Markdown table of per-commit deltas and speedup ratios between two
+// previously written reports, suitable for pasting into a PR. Commits are
+// matched between the two reports by hash (falling back to label), not by
+// position, so reports with differently ordered or mismatched commit lists
+// still compare like for like; anything left unmatched is reported as a
+// warning instead of silently compared against the wrong commit.
+func runReportCommand(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: chess-bench report <a.json> <b.json>")
+	}
+
+	a, err := readReport(args[0])
+	if err != nil {
+		return err
+	}
+	b, err := readReport(args[1])
+	if err != nil {
+		return err
+	}
+
+	pairs, unmatchedA, unmatchedB := matchResults(a.Results, b.Results)
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "| Commit | Label | Mean A (ms) | Mean B (ms) | Δ | Speedup | p-value |")
+	fmt.Fprintln(w, "|---|---|---|---|---|---|---|")
+
+	for _, pair := range pairs {
+		ra, rb := pair[0], pair[1]
+
+		delta := (rb.Mean - ra.Mean) / ra.Mean * 100
+		speedup := ra.Mean / rb.Mean
+		_, _, p := welchTTest(ra.Runs, rb.Runs)
+
+		sig := ""
+		switch {
+		case p < 0.01:
+			sig = "**"
+		case p < 0.05:
+			sig = "*"
+		}
+
+		fmt.Fprintf(w, "| %.7s | %s | %.2f | %.2f | %+.1f%% | %.2fx | %.4f%s |\n",
+			ra.CommitHash, ra.Label, ra.Mean, rb.Mean, delta, speedup, p, sig)
+	}
+
+	for _, ra := range unmatchedA {
+		fmt.Fprintf(os.Stderr, "warning: no match in %s for commit %.7s (%s)\n", args[1], ra.CommitHash, ra.Label)
+	}
+	for _, rb := range unmatchedB {
+		fmt.Fprintf(os.Stderr, "warning: no match in %s for commit %.7s (%s)\n", args[0], rb.CommitHash, rb.Label)
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import "testing"
+
+func TestMatchResultsByHash(t *testing.T) {
+	a := []benchResult{{CommitHash: "aaa", Label: "base"}, {CommitHash: "bbb", Label: "head"}}
+	b := []benchResult{{CommitHash: "bbb", Label: "head"}, {CommitHash: "aaa", Label: "base"}}
+
+	pairs, unmatchedA, unmatchedB := matchResults(a, b)
+
+	if len(pairs) != 2 {
+		t.Fatalf("got %d pairs, want 2", len(pairs))
+	}
+	if len(unmatchedA) != 0 || len(unmatchedB) != 0 {
+		t.Fatalf("got unmatchedA=%v unmatchedB=%v, want none", unmatchedA, unmatchedB)
+	}
+	if pairs[0][0].CommitHash != "aaa" || pairs[0][1].CommitHash != "aaa" {
+		t.Errorf("pairs[0] = %v, want both sides to be commit aaa", pairs[0])
+	}
+	if pairs[1][0].CommitHash != "bbb" || pairs[1][1].CommitHash != "bbb" {
+		t.Errorf("pairs[1] = %v, want both sides to be commit bbb", pairs[1])
+	}
+}
+
+func TestMatchResultsFallsBackToLabel(t *testing.T) {
+	a := []benchResult{{CommitHash: "aaa", Label: "base"}}
+	b := []benchResult{{CommitHash: "aaa-rebuilt", Label: "base"}}
+
+	pairs, unmatchedA, unmatchedB := matchResults(a, b)
+
+	if len(pairs) != 1 {
+		t.Fatalf("got %d pairs, want 1 (matched by label)", len(pairs))
+	}
+	if len(unmatchedA) != 0 || len(unmatchedB) != 0 {
+		t.Fatalf("got unmatchedA=%v unmatchedB=%v, want none", unmatchedA, unmatchedB)
+	}
+}
+
+func TestMatchResultsReportsUnmatched(t *testing.T) {
+	a := []benchResult{{CommitHash: "aaa", Label: "base"}, {CommitHash: "ccc", Label: "only-in-a"}}
+	b := []benchResult{{CommitHash: "aaa", Label: "base"}, {CommitHash: "ddd", Label: "only-in-b"}}
+
+	pairs, unmatchedA, unmatchedB := matchResults(a, b)
+
+	if len(pairs) != 1 {
+		t.Fatalf("got %d pairs, want 1", len(pairs))
+	}
+	if len(unmatchedA) != 1 || unmatchedA[0].CommitHash != "ccc" {
+		t.Errorf("unmatchedA = %v, want [ccc]", unmatchedA)
+	}
+	if len(unmatchedB) != 1 || unmatchedB[0].CommitHash != "ddd" {
+		t.Errorf("unmatchedB = %v, want [ddd]", unmatchedB)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// runRecord is a single timed invocation of a commit's run command, emitted
+// so that raw per-run data can be diffed across benchmarking sessions later.
+type runRecord struct {
+	Index     int     `json:"index"`
+	Millis    float64 `json:"millis"`
+	Timestamp int64   `json:"timestamp"`
+	Commit    string  `json:"commit"`
+	Label     string  `json:"label"`
+	Warmup    bool    `json:"warmup"`
+}
+
+func randIndex(n int) int {
+	return rand.Intn(n)
+}
+
+// trimOutliers sorts data and drops the top and bottom trimPercent fraction
+// (e.g. 0.1 trims the lowest and highest 10%) before returning the remainder.
+// A trimPercent of 0 returns data unchanged.
+func trimOutliers(data []float64, trimPercent float64) []float64 {
+	if trimPercent <= 0 || len(data) < 3 {
+		return data
+	}
+
+	trimmed := make([]float64, len(data))
+	copy(trimmed, data)
+	sort.Float64s(trimmed)
+
+	cut := int(float64(len(trimmed)) * trimPercent)
+	if cut*2 >= len(trimmed) {
+		return trimmed
+	}
+
+	return trimmed[cut : len(trimmed)-cut]
+}
+
+// bootstrapCI computes a percentile bootstrap confidence interval for the
+// mean of data, resampling with replacement `iterations` times. confidence
+// is the two-sided coverage, e.g. 0.95 for a 95% CI.
+func bootstrapCI(data []float64, iterations int, confidence float64) (lo, hi float64) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+	if iterations <= 0 {
+		iterations = 2000
+	}
+
+	means := make([]float64, iterations)
+	sample := make([]float64, len(data))
+	for i := 0; i < iterations; i++ {
+		for j := range sample {
+			sample[j] = data[randIndex(len(data))]
+		}
+		means[i] = stat.Mean(sample, nil)
+	}
+	sort.Float64s(means)
+
+	tail := (1 - confidence) / 2
+	loIdx := int(tail * float64(iterations))
+	hiIdx := int((1 - tail) * float64(iterations))
+	if hiIdx >= iterations {
+		hiIdx = iterations - 1
+	}
+
+	return means[loIdx], means[hiIdx]
+}
+
+// welchTTest runs Welch's t-test between two independent samples and returns
+// the t-statistic, the (Welch-Satterthwaite) degrees of freedom, and a
+// two-tailed p-value approximated via the normal CDF, which is accurate for
+// the sample sizes chess-bench typically collects (runs >= 10).
+func welchTTest(a, b []float64) (t, df, p float64) {
+	if len(a) < 2 || len(b) < 2 {
+		return 0, 0, 1
+	}
+
+	meanA, varA := stat.MeanVariance(a, nil)
+	meanB, varB := stat.MeanVariance(b, nil)
+	nA, nB := float64(len(a)), float64(len(b))
+
+	se := math.Sqrt(varA/nA + varB/nB)
+	if se == 0 {
+		return 0, 0, 1
+	}
+
+	t = (meanA - meanB) / se
+	df = math.Pow(varA/nA+varB/nB, 2) /
+		(math.Pow(varA/nA, 2)/(nA-1) + math.Pow(varB/nB, 2)/(nB-1))
+	p = 2 * (1 - normalCDF(math.Abs(t)))
+
+	return t, df, p
+}
+
+func normalCDF(x float64) float64 {
+	return 0.5 * (1 + math.Erf(x/math.Sqrt2))
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTrimOutliers(t *testing.T) {
+	cases := []struct {
+		name        string
+		data        []float64
+		trimPercent float64
+		want        []float64
+	}{
+		{"zero trim returns data unchanged", []float64{5, 1, 3}, 0, []float64{5, 1, 3}},
+		{"fewer than 3 samples returns data unchanged", []float64{2, 1}, 0.5, []float64{2, 1}},
+		{"trims top and bottom 10%", []float64{10, 1, 2, 3, 4, 5, 6, 7, 8, 9}, 0.1, []float64{2, 3, 4, 5, 6, 7, 8, 9}},
+		{"cut covering the whole slice returns it sorted instead", []float64{3, 1, 2}, 0.9, []float64{1, 2, 3}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := trimOutliers(c.data, c.trimPercent)
+			if len(got) != len(c.want) {
+				t.Fatalf("trimOutliers(%v, %v) = %v, want %v", c.data, c.trimPercent, got, c.want)
+			}
+			for i := range got {
+				if got[i] != c.want[i] {
+					t.Fatalf("trimOutliers(%v, %v) = %v, want %v", c.data, c.trimPercent, got, c.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBootstrapCI(t *testing.T) {
+	data := []float64{10, 10, 10, 10, 10}
+	lo, hi := bootstrapCI(data, 500, 0.95)
+	if lo != 10 || hi != 10 {
+		t.Fatalf("bootstrapCI on constant data = (%v, %v), want (10, 10)", lo, hi)
+	}
+
+	if lo, hi := bootstrapCI(nil, 500, 0.95); lo != 0 || hi != 0 {
+		t.Fatalf("bootstrapCI(nil) = (%v, %v), want (0, 0)", lo, hi)
+	}
+
+	data = []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	lo, hi = bootstrapCI(data, 2000, 0.95)
+	if lo > hi {
+		t.Fatalf("bootstrapCI returned lo=%v > hi=%v", lo, hi)
+	}
+	if lo < 1 || hi > 10 {
+		t.Fatalf("bootstrapCI(%v) = (%v, %v), want bounds within [1, 10]", data, lo, hi)
+	}
+}
+
+func TestWelchTTest(t *testing.T) {
+	a := []float64{1, 1, 1, 1, 1}
+	b := []float64{1, 1, 1, 1, 1}
+	if _, _, p := welchTTest(a, b); p != 1 {
+		t.Fatalf("welchTTest on identical zero-variance samples: p = %v, want 1", p)
+	}
+
+	if _, _, p := welchTTest([]float64{1}, []float64{1, 2, 3}); p != 1 {
+		t.Fatalf("welchTTest with a sample size < 2: p = %v, want 1", p)
+	}
+
+	a = []float64{100, 101, 99, 100, 102, 98}
+	b = []float64{200, 201, 199, 200, 202, 198}
+	_, _, p := welchTTest(a, b)
+	if p >= 0.01 {
+		t.Fatalf("welchTTest on clearly separated samples: p = %v, want a small p-value", p)
+	}
+}
+
+func TestNormalCDF(t *testing.T) {
+	if got := normalCDF(0); math.Abs(got-0.5) > 1e-9 {
+		t.Fatalf("normalCDF(0) = %v, want 0.5", got)
+	}
+	if got := normalCDF(-10); got > 0.01 {
+		t.Fatalf("normalCDF(-10) = %v, want close to 0", got)
+	}
+	if got := normalCDF(10); got < 0.99 {
+		t.Fatalf("normalCDF(10) = %v, want close to 1", got)
+	}
+}
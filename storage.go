@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"google.golang.org/api/iterator"
+)
+
+// Storage is a content store for built artifacts, keyed by an opaque string
+// such as "{commitHash}/{settingsSHA}". Implementations upload and download
+// whole directory trees so a fleet of benchmark runners can share prebuilt
+// binaries instead of every machine re-cloning and rebuilding every commit.
+type Storage interface {
+	// Upload copies the contents of localDir (recursively) under key.
+	Upload(ctx context.Context, key string, localDir string) error
+	// Download copies everything stored under key into localDir, creating
+	// it if necessary. It returns an error satisfying errors.Is(err,
+	// os.ErrNotExist) if key doesn't exist.
+	Download(ctx context.Context, key string, localDir string) error
+	// Exists reports whether anything is stored under key.
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// NewStorage selects a Storage backend from a URI's scheme: file://, gs://
+// or s3://, as configured by appConfig.ArtifactURL in config.json.
+func NewStorage(uri string) (Storage, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("Error parsing artifact store URL: %v", err)
+	}
+
+	switch u.Scheme {
+	case "file", "":
+		return &fileStorage{root: path.Join(u.Host, u.Path)}, nil
+	case "gs":
+		return newGCSStorage(u.Host, strings.TrimPrefix(u.Path, "/"))
+	case "s3":
+		return newS3Storage(u.Host, strings.TrimPrefix(u.Path, "/"))
+	default:
+		return nil, fmt.Errorf("Unsupported artifact store scheme: %v", u.Scheme)
+	}
+}
+
+// fileStorage stores artifacts as a plain directory tree under root, for
+// single-machine use or a shared NFS/SMB mount.
+type fileStorage struct {
+	root string
+}
+
+func (s *fileStorage) Exists(ctx context.Context, key string) (bool, error) {
+	return dirExists(path.Join(s.root, key)), nil
+}
+
+func (s *fileStorage) Upload(ctx context.Context, key string, localDir string) error {
+	return copyTree(localDir, path.Join(s.root, key))
+}
+
+func (s *fileStorage) Download(ctx context.Context, key string, localDir string) error {
+	src := path.Join(s.root, key)
+	if !dirExists(src) {
+		return fmt.Errorf("Key not found in file storage: %v: %w", key, os.ErrNotExist)
+	}
+
+	return copyTree(src, localDir)
+}
+
+func copyTree(src, dst string) error {
+	return filepath.Walk(src, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := path.Join(dst, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, os.ModePerm)
+		}
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+			return err
+		}
+
+		return os.WriteFile(target, data, info.Mode())
+	})
+}
+
+// gcsStorage stores artifacts as objects under gs://bucket/prefix/key/....
+type gcsStorage struct {
+	client *storage.Client
+	bucket string
+	prefix string
+}
+
+func newGCSStorage(bucket, prefix string) (*gcsStorage, error) {
+	client, err := storage.NewClient(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("Error creating GCS client: %v", err)
+	}
+
+	return &gcsStorage{client: client, bucket: bucket, prefix: prefix}, nil
+}
+
+func (s *gcsStorage) objectName(key, rel string) string {
+	return path.Join(s.prefix, key, rel)
+}
+
+func (s *gcsStorage) Exists(ctx context.Context, key string) (bool, error) {
+	it := s.client.Bucket(s.bucket).Objects(ctx, &storage.Query{Prefix: path.Join(s.prefix, key) + "/"})
+	_, err := it.Next()
+	if err == iterator.Done {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *gcsStorage) Upload(ctx context.Context, key string, localDir string) error {
+	return filepath.Walk(localDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(localDir, p)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		w := s.client.Bucket(s.bucket).Object(s.objectName(key, rel)).NewWriter(ctx)
+		if _, err := io.Copy(w, f); err != nil {
+			return err
+		}
+
+		return w.Close()
+	})
+}
+
+func (s *gcsStorage) Download(ctx context.Context, key string, localDir string) error {
+	it := s.client.Bucket(s.bucket).Objects(ctx, &storage.Query{Prefix: path.Join(s.prefix, key) + "/"})
+	found := false
+	for {
+		obj, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+
+		rel := strings.TrimPrefix(obj.Name, path.Join(s.prefix, key)+"/")
+		target := path.Join(localDir, rel)
+		if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+			return err
+		}
+
+		r, err := s.client.Bucket(s.bucket).Object(obj.Name).NewReader(ctx)
+		if err != nil {
+			return err
+		}
+
+		out, err := os.Create(target)
+		if err != nil {
+			r.Close()
+			return err
+		}
+
+		_, err = io.Copy(out, r)
+		r.Close()
+		out.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("Key not found in GCS storage: %v: %w", key, os.ErrNotExist)
+	}
+
+	return nil
+}
+
+// s3Storage stores artifacts as objects under s3://bucket/prefix/key/....
+type s3Storage struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+func newS3Storage(bucket, prefix string) (*s3Storage, error) {
+	cfg, err := config.LoadDefaultConfig(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("Error loading AWS config: %v", err)
+	}
+
+	return &s3Storage{client: s3.NewFromConfig(cfg), bucket: bucket, prefix: prefix}, nil
+}
+
+func (s *s3Storage) objectKey(key, rel string) string {
+	return path.Join(s.prefix, key, rel)
+}
+
+func (s *s3Storage) Exists(ctx context.Context, key string) (bool, error) {
+	// MaxKeys caps the *page size* at 1, not the total match count, so a
+	// single (possibly truncated) page is enough to answer "does anything
+	// exist under this prefix" - no need to paginate through the rest.
+	out, err := s.client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{
+		Bucket:  aws.String(s.bucket),
+		Prefix:  aws.String(path.Join(s.prefix, key) + "/"),
+		MaxKeys: aws.Int32(1),
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return len(out.Contents) > 0, nil
+}
+
+func (s *s3Storage) Upload(ctx context.Context, key string, localDir string) error {
+	return filepath.Walk(localDir, func(p string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(localDir, p)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+			Bucket: aws.String(s.bucket),
+			Key:    aws.String(s.objectKey(key, rel)),
+			Body:   f,
+		})
+		return err
+	})
+}
+
+func (s *s3Storage) Download(ctx context.Context, key string, localDir string) error {
+	prefix := path.Join(s.prefix, key) + "/"
+
+	found := false
+	paginator := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return err
+		}
+
+		for _, obj := range page.Contents {
+			found = true
+
+			rel := strings.TrimPrefix(*obj.Key, prefix)
+			target := path.Join(localDir, rel)
+			if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+				return err
+			}
+
+			getOut, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+				Bucket: aws.String(s.bucket),
+				Key:    obj.Key,
+			})
+			if err != nil {
+				return err
+			}
+
+			file, err := os.Create(target)
+			if err != nil {
+				getOut.Body.Close()
+				return err
+			}
+
+			_, err = io.Copy(file, getOut.Body)
+			getOut.Body.Close()
+			file.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("Key not found in S3 storage: %v: %w", key, os.ErrNotExist)
+	}
+
+	return nil
+}
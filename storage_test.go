@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"testing"
+)
+
+func TestFileStorageRoundTrip(t *testing.T) {
+	root := t.TempDir()
+	store := &fileStorage{root: root}
+	ctx := context.Background()
+
+	key := "deadbeef/abc123"
+
+	if exists, err := store.Exists(ctx, key); err != nil || exists {
+		t.Fatalf("Exists before upload = (%v, %v), want (false, nil)", exists, err)
+	}
+
+	localDir := t.TempDir()
+	if err := os.WriteFile(path.Join(localDir, "engine"), []byte("binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(path.Join(localDir, "nested"), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path.Join(localDir, "nested", "extra"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Upload(ctx, key, localDir); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	if exists, err := store.Exists(ctx, key); err != nil || !exists {
+		t.Fatalf("Exists after upload = (%v, %v), want (true, nil)", exists, err)
+	}
+
+	downloadDir := t.TempDir()
+	if err := store.Download(ctx, key, downloadDir); err != nil {
+		t.Fatalf("Download failed: %v", err)
+	}
+
+	got, err := os.ReadFile(path.Join(downloadDir, "engine"))
+	if err != nil || string(got) != "binary" {
+		t.Errorf("downloaded engine = %q, %v, want %q, nil", got, err, "binary")
+	}
+
+	got, err = os.ReadFile(path.Join(downloadDir, "nested", "extra"))
+	if err != nil || string(got) != "data" {
+		t.Errorf("downloaded nested/extra = %q, %v, want %q, nil", got, err, "data")
+	}
+}
+
+func TestFileStorageDownloadMissingKey(t *testing.T) {
+	store := &fileStorage{root: t.TempDir()}
+
+	err := store.Download(context.Background(), "does-not-exist", t.TempDir())
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Download of a missing key: err = %v, want an os.ErrNotExist-satisfying error", err)
+	}
+}
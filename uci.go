@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// searchResult is the outcome of benchmarking a single EPD position: the
+// final depth reached, nodes searched, nodes-per-second, search time and the
+// move the engine settled on.
+type searchResult struct {
+	FEN      string
+	Depth    int
+	Nodes    int64
+	NPS      int64
+	TimeMs   int64
+	BestMove string
+}
+
+// uciEngine is a single long-lived engine process spoken to over UCI, so
+// that process startup cost isn't included in search timings the way a
+// fresh `bash -c runCmd` per position would be.
+type uciEngine struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Scanner
+}
+
+// startUCIEngine launches binCmd (a shell command, e.g. "./bin/engine") in
+// dir on host ("" for localhost) and performs the "uci" / "isready"
+// handshake.
+func startUCIEngine(host, dir, binCmd string) (*uciEngine, error) {
+	cmd := newShellCmd(host, dir, binCmd)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("Error opening engine stdin: %v", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("Error opening engine stdout: %v", err)
+	}
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("Error starting engine: %v", err)
+	}
+
+	e := &uciEngine{cmd: cmd, stdin: stdin, stdout: bufio.NewScanner(stdout)}
+	e.stdout.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	if err := e.send("uci"); err != nil {
+		return nil, err
+	}
+	if err := e.waitFor("uciok"); err != nil {
+		return nil, fmt.Errorf("Error waiting for uciok: %v", err)
+	}
+
+	if err := e.send("isready"); err != nil {
+		return nil, err
+	}
+	if err := e.waitFor("readyok"); err != nil {
+		return nil, fmt.Errorf("Error waiting for readyok: %v", err)
+	}
+
+	return e, nil
+}
+
+func (e *uciEngine) send(line string) error {
+	_, err := io.WriteString(e.stdin, line+"\n")
+	return err
+}
+
+func (e *uciEngine) waitFor(token string) error {
+	for e.stdout.Scan() {
+		if strings.Contains(e.stdout.Text(), token) {
+			return nil
+		}
+	}
+	return e.stdout.Err()
+}
+
+// Close sends "quit" and waits for the engine process to exit.
+func (e *uciEngine) Close() error {
+	_ = e.send("quit")
+	return e.cmd.Wait()
+}
+
+// search positions the engine on fen (or the start position when fen is
+// "startpos") and runs a search in goMode ("depth" or "nodes"), parsing the
+// engine's "info ..." lines for the final depth/nodes/nps/time reached before
+// "bestmove" is emitted. In "bench" mode fen is not used: the engine's
+// built-in bench runs instead and its summary lines are parsed.
+func (e *uciEngine) search(fen string, goMode string, value int) (searchResult, error) {
+	result := searchResult{FEN: fen}
+
+	if goMode == "bench" {
+		if err := e.send("bench"); err != nil {
+			return result, err
+		}
+	} else {
+		if fen == "startpos" {
+			if err := e.send("position startpos"); err != nil {
+				return result, err
+			}
+		} else {
+			if err := e.send(fmt.Sprintf("position fen %s", fen)); err != nil {
+				return result, err
+			}
+		}
+
+		switch goMode {
+		case "nodes":
+			if err := e.send(fmt.Sprintf("go nodes %d", value)); err != nil {
+				return result, err
+			}
+		default:
+			if err := e.send(fmt.Sprintf("go depth %d", value)); err != nil {
+				return result, err
+			}
+		}
+	}
+
+	for e.stdout.Scan() {
+		line := e.stdout.Text()
+
+		if strings.HasPrefix(line, "info ") {
+			parseInfoLine(line, &result)
+			continue
+		}
+		if strings.HasPrefix(line, "bestmove") {
+			fields := strings.Fields(line)
+			if len(fields) >= 2 {
+				result.BestMove = fields[1]
+			}
+			// "bench" reports one bestmove per internal test position, well
+			// before its final summary lines - only depth/nodes mode's
+			// single search ends here.
+			if goMode != "bench" {
+				break
+			}
+			continue
+		}
+		if goMode == "bench" {
+			if parseBenchLine(line, &result) {
+				break
+			}
+		}
+	}
+
+	return result, e.stdout.Err()
+}
+
+// parseInfoLine pulls depth/nodes/nps/time out of a UCI "info ..." line,
+// overwriting the running result with the latest values seen.
+func parseInfoLine(line string, result *searchResult) {
+	fields := strings.Fields(line)
+	for i := 0; i < len(fields)-1; i++ {
+		value, err := strconv.ParseInt(fields[i+1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		switch fields[i] {
+		case "depth":
+			result.Depth = int(value)
+		case "nodes":
+			result.Nodes = value
+		case "nps":
+			result.NPS = value
+		case "time":
+			result.TimeMs = value
+		}
+	}
+}
+
+// parseBenchLine pulls totals out of the summary lines Stockfish/Ethereal
+// print at the end of their "bench" command, e.g.:
+//
+//	Total time (ms) : 12345
+//	Nodes searched  : 123456789
+//	Nodes/second    : 9876543
+//
+// It reports whether the line it just parsed was the last line of that
+// summary ("Nodes/second"), so callers know to stop scanning rather than
+// block waiting for a process that has no more output queued.
+func parseBenchLine(line string, result *searchResult) bool {
+	parts := strings.SplitN(line, ":", 2)
+	if len(parts) != 2 {
+		return false
+	}
+
+	label := strings.ToLower(strings.TrimSpace(parts[0]))
+	value, err := strconv.ParseInt(strings.TrimSpace(parts[1]), 10, 64)
+	if err != nil {
+		return false
+	}
+
+	switch {
+	case strings.Contains(label, "nodes/second"):
+		result.NPS = value
+		return true
+	case strings.Contains(label, "nodes"):
+		result.Nodes = value
+	case strings.Contains(label, "time"):
+		result.TimeMs = value
+	}
+
+	return false
+}
+
+// loadEPDSuite reads a suite of EPD positions, one per line, using only the
+// board/side/castling/en-passant FEN fields (the first 4 whitespace-delimited
+// fields) from each - the opcodes that follow (bm, id, ...) are separated
+// from the FEN by a space, not a ";", which only separates opcodes from each
+// other. An empty path benchmarks a single search from the start position.
+func loadEPDSuite(path string) ([]string, error) {
+	if path == "" {
+		return []string{"startpos"}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading EPD suite: %v", err)
+	}
+
+	var positions []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+
+		fen := strings.Join(fields[:4], " ")
+		positions = append(positions, fen)
+	}
+
+	return positions, nil
+}
+
+// runUCICommit benchmarks a commit in runDir on host ("" for localhost) by
+// speaking UCI to its RunCmd once, rather than timing a fresh process per
+// run. It returns the per-position NPS so callers can reuse the same
+// trim/CI/regression pipeline as the wall-clock path.
+func runUCICommit(c *commit, partialHash, host, runDir string) ([]float64, error) {
+	runCmd := strings.ReplaceAll(c.Settings.RunCmd, "%p", strconv.Itoa(c.Settings.Depth))
+	runCmd = isolatedRunCmd(runCmd, c.Settings)
+
+	positions, err := loadEPDSuite(c.Settings.EPDFile)
+	if err != nil {
+		return nil, err
+	}
+
+	engine, err := startUCIEngine(host, runDir, runCmd)
+	if err != nil {
+		return nil, fmt.Errorf("Error starting UCI engine: %v", err)
+	}
+	defer engine.Close()
+
+	goMode := c.Settings.GoMode
+	if goMode == "" {
+		goMode = "depth"
+	}
+
+	fmt.Printf("Benchmarking %s... (%s, uci/%s)\n", partialHash, c.Label, goMode)
+
+	results := make([]searchResult, 0, len(positions))
+	for _, fen := range positions {
+		res, err := engine.search(fen, goMode, c.Settings.Depth)
+		if err != nil {
+			return nil, fmt.Errorf("Error searching position %q: %v", fen, err)
+		}
+		results = append(results, res)
+		fmt.Printf("  depth=%d nodes=%d nps=%d time=%dms bestmove=%s\n", res.Depth, res.Nodes, res.NPS, res.TimeMs, res.BestMove)
+	}
+
+	meanNPS, stdDevNPS := aggregateSearchResults(results)
+	fmt.Printf("Mean NPS: %.0f (σ=%.0f) across %d position(s)\n", meanNPS, stdDevNPS, len(results))
+
+	nps := make([]float64, len(results))
+	for i, r := range results {
+		nps[i] = float64(r.NPS)
+	}
+
+	return nps, nil
+}
+
+// aggregateSearchResults summarizes NPS across an EPD suite run using the
+// same gonum/stat helpers as the wall-clock benchmarking path.
+func aggregateSearchResults(results []searchResult) (meanNPS, stdDevNPS float64) {
+	nps := make([]float64, len(results))
+	for i, r := range results {
+		nps[i] = float64(r.NPS)
+	}
+
+	return stat.Mean(nps, nil), stat.StdDev(nps, nil)
+}
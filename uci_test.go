@@ -0,0 +1,73 @@
+package main
+
+import "testing"
+
+func TestParseInfoLine(t *testing.T) {
+	var result searchResult
+	parseInfoLine("info depth 12 seldepth 18 multipv 1 score cp 34 nodes 123456 nps 987654 time 125 pv e2e4 e7e5", &result)
+
+	if result.Depth != 12 {
+		t.Errorf("Depth = %d, want 12", result.Depth)
+	}
+	if result.Nodes != 123456 {
+		t.Errorf("Nodes = %d, want 123456", result.Nodes)
+	}
+	if result.NPS != 987654 {
+		t.Errorf("NPS = %d, want 987654", result.NPS)
+	}
+	if result.TimeMs != 125 {
+		t.Errorf("TimeMs = %d, want 125", result.TimeMs)
+	}
+
+	// A later line overwrites the running result with its own values.
+	parseInfoLine("info depth 13 nodes 200000 nps 1000000 time 200", &result)
+	if result.Depth != 13 || result.Nodes != 200000 || result.NPS != 1000000 || result.TimeMs != 200 {
+		t.Errorf("second parseInfoLine did not overwrite previous values: %+v", result)
+	}
+}
+
+func TestParseInfoLineIgnoresNonNumericFields(t *testing.T) {
+	var result searchResult
+	parseInfoLine("info string NNUE evaluation enabled", &result)
+	if result.Depth != 0 || result.Nodes != 0 || result.NPS != 0 || result.TimeMs != 0 {
+		t.Errorf("parseInfoLine on a non-numeric line should leave result untouched, got %+v", result)
+	}
+}
+
+func TestParseBenchLine(t *testing.T) {
+	var result searchResult
+
+	if done := parseBenchLine("Total time (ms) : 12345", &result); done {
+		t.Errorf("parseBenchLine on the time line reported done, want false")
+	}
+	if result.TimeMs != 12345 {
+		t.Errorf("TimeMs = %d, want 12345", result.TimeMs)
+	}
+
+	if done := parseBenchLine("Nodes searched  : 123456789", &result); done {
+		t.Errorf("parseBenchLine on the nodes line reported done, want false")
+	}
+	if result.Nodes != 123456789 {
+		t.Errorf("Nodes = %d, want 123456789", result.Nodes)
+	}
+
+	if done := parseBenchLine("Nodes/second    : 9876543", &result); !done {
+		t.Errorf("parseBenchLine on the nodes/second line reported not done, want true")
+	}
+	if result.NPS != 9876543 {
+		t.Errorf("NPS = %d, want 9876543", result.NPS)
+	}
+}
+
+func TestParseBenchLineIgnoresUnrelatedLines(t *testing.T) {
+	var result searchResult
+	if done := parseBenchLine("===========================", &result); done {
+		t.Errorf("parseBenchLine on a non-stat line reported done, want false")
+	}
+	if done := parseBenchLine("Position 3/5", &result); done {
+		t.Errorf("parseBenchLine on a non-colon-separated stat line reported done, want false")
+	}
+	if result.NPS != 0 || result.Nodes != 0 || result.TimeMs != 0 {
+		t.Errorf("parseBenchLine touched result on unrelated input: %+v", result)
+	}
+}